@@ -0,0 +1,97 @@
+// Package handler dispatches downloaded files to user-configured external
+// viewers/post-processors, keyed by mediatype.
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Config is a single mediatype's handler: the command to run, whether to
+// prompt before running it, and whether it should fire automatically after
+// every matching download.
+type Config struct {
+	Cmd              []string
+	NoPrompt         bool
+	RunAfterDownload bool
+}
+
+// Dispatch runs the handler configured for mediatype against path, expanding
+// "{}" (or "{path}") placeholders in cmd's argv. It is a no-op if no handler
+// is configured for mediatype, or if the user declines the confirmation
+// prompt (skipped when the handler's NoPrompt is set). timeout bounds the
+// spawned process; a non-positive timeout means no bound.
+//
+// Dispatch is fire-and-forget: it returns once the process has started, it
+// does not wait for the viewer to exit. The process is still waited on in
+// the background so its context (and any timeout) stays alive until it
+// actually exits, rather than being killed the instant Dispatch returns.
+func Dispatch(ctx context.Context, handlers map[string]Config, mediatype, path string, timeout time.Duration) error {
+	h, ok := handlers[mediatype]
+	if !ok || len(h.Cmd) == 0 {
+		return nil
+	}
+
+	if !h.NoPrompt {
+		proceed, err := confirm(os.Stdin, os.Stdout, h.Cmd, path)
+		if err != nil {
+			return fmt.Errorf("handler: failed to read confirmation: %w", err)
+		}
+		if !proceed {
+			return nil
+		}
+	}
+
+	runCtx := ctx
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	argv := make([]string, len(h.Cmd))
+	for i, arg := range h.Cmd {
+		argv[i] = expandPath(arg, path)
+	}
+
+	cmd := exec.CommandContext(runCtx, argv[0], argv[1:]...) //nolint:gosec // argv comes from user config
+	if err := cmd.Start(); err != nil {
+		if cancel != nil {
+			cancel()
+		}
+		return fmt.Errorf("handler: failed to start %q for %s: %w", h.Cmd[0], mediatype, err)
+	}
+
+	go func() {
+		if cancel != nil {
+			defer cancel()
+		}
+		_ = cmd.Wait()
+	}()
+
+	return nil
+}
+
+// confirm asks the user whether to run cmd against path, defaulting to yes
+// on a bare Enter. It is split out from Dispatch so it can be exercised
+// without a real terminal.
+func confirm(in io.Reader, out io.Writer, cmd []string, path string) (bool, error) {
+	fmt.Fprintf(out, "Open %s with %q? [Y/n] ", path, strings.Join(cmd, " "))
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	line = strings.ToLower(strings.TrimSpace(line))
+	return line == "" || line == "y" || line == "yes", nil
+}
+
+func expandPath(arg, path string) string {
+	arg = strings.ReplaceAll(arg, "{path}", path)
+	arg = strings.ReplaceAll(arg, "{}", path)
+	return arg
+}
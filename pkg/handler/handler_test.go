@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDispatchRunsConfiguredHandler(t *testing.T) {
+	handlers := map[string]Config{
+		"text/plain": {Cmd: []string{"true"}, NoPrompt: true},
+	}
+
+	if err := Dispatch(context.Background(), handlers, "text/plain", "/tmp/post.txt", time.Second); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+}
+
+func TestDispatchNoHandlerConfigured(t *testing.T) {
+	if err := Dispatch(context.Background(), map[string]Config{}, "video/mp4", "/tmp/post.mp4", 0); err != nil {
+		t.Fatalf("Dispatch() with no handler should be a no-op, got error = %v", err)
+	}
+}
+
+func TestDispatchStartFailure(t *testing.T) {
+	handlers := map[string]Config{
+		"video/mp4": {Cmd: []string{"definitely-not-a-real-binary"}, NoPrompt: true},
+	}
+
+	if err := Dispatch(context.Background(), handlers, "video/mp4", "/tmp/post.mp4", time.Second); err == nil {
+		t.Fatal("Dispatch() expected an error for an unresolvable command, got nil")
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	cases := []struct {
+		arg, path, want string
+	}{
+		{"{}", "/tmp/post.mp4", "/tmp/post.mp4"},
+		{"{path}", "/tmp/post.mp4", "/tmp/post.mp4"},
+		{"--file={path}", "/tmp/post.mp4", "--file=/tmp/post.mp4"},
+	}
+	for _, c := range cases {
+		if got := expandPath(c.arg, c.path); got != c.want {
+			t.Errorf("expandPath(%q, %q) = %q, want %q", c.arg, c.path, got, c.want)
+		}
+	}
+}
+
+func TestConfirmDefaultsToYesOnEnter(t *testing.T) {
+	var out strings.Builder
+	ok, err := confirm(strings.NewReader("\n"), &out, []string{"mpv"}, "/tmp/post.mp4")
+	if err != nil {
+		t.Fatalf("confirm() error = %v", err)
+	}
+	if !ok {
+		t.Error("confirm() with a bare newline should default to yes")
+	}
+}
+
+func TestConfirmDeclines(t *testing.T) {
+	var out strings.Builder
+	ok, err := confirm(strings.NewReader("n\n"), &out, []string{"mpv"}, "/tmp/post.mp4")
+	if err != nil {
+		t.Fatalf("confirm() error = %v", err)
+	}
+	if ok {
+		t.Error("confirm() with \"n\" should decline")
+	}
+}
@@ -0,0 +1,101 @@
+// Package hooks runs user-configured commands around the download
+// lifecycle.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Event identifies a point in the download lifecycle a hook can fire on.
+type Event string
+
+const (
+	PreDownload  Event = "pre_download"
+	PostDownload Event = "post_download"
+	PostTarget   Event = "post_target"
+	OnError      Event = "on_error"
+)
+
+// Config is a single configured hook.
+type Config struct {
+	Cmd             []string
+	Env             map[string]string
+	Timeout         time.Duration
+	MediatypeFilter []string // if non-empty, the hook only fires for these mediatypes
+}
+
+// Vars holds the placeholder values substituted into a hook's argv and env.
+// A zero-valued field expands its placeholder to the empty string.
+type Vars struct {
+	Path       string
+	Author     string
+	PostID     string
+	Mediatype  string
+	DurationMS string
+	Quality    string
+}
+
+func (v Vars) expand(s string) string {
+	r := strings.NewReplacer(
+		"{path}", v.Path,
+		"{author}", v.Author,
+		"{post_id}", v.PostID,
+		"{mediatype}", v.Mediatype,
+		"{duration_ms}", v.DurationMS,
+		"{quality}", v.Quality,
+	)
+	return r.Replace(s)
+}
+
+// Run executes hook with vars expanded into its argv and env, capturing
+// combined output into the returned string for the caller to log. It
+// returns early (without error) if hook.MediatypeFilter is set and doesn't
+// include vars.Mediatype.
+func Run(ctx context.Context, hook Config, vars Vars) (string, error) {
+	if len(hook.Cmd) == 0 {
+		return "", nil
+	}
+	if len(hook.MediatypeFilter) > 0 && !contains(hook.MediatypeFilter, vars.Mediatype) {
+		return "", nil
+	}
+
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	argv := make([]string, len(hook.Cmd))
+	for i, arg := range hook.Cmd {
+		argv[i] = vars.expand(arg)
+	}
+
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...) //nolint:gosec // argv comes from user config
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	for k, v := range hook.Env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, vars.expand(v)))
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("hooks: %s failed: %w", hook.Cmd[0], err)
+	}
+	return out.String(), nil
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
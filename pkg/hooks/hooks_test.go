@@ -0,0 +1,71 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunExpandsPlaceholdersInArgvAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.txt")
+
+	hook := Config{
+		Cmd: []string{"sh", "-c", `printf '%s %s' "$1" "$MEDIATYPE" > ` + out, "_", "{post_id}"},
+		Env: map[string]string{"MEDIATYPE": "{mediatype}"},
+	}
+	vars := Vars{PostID: "12345", Mediatype: "video/mp4"}
+
+	if _, err := Run(context.Background(), hook, vars); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != "12345 video/mp4" {
+		t.Errorf("Run() wrote %q, want %q", got, "12345 video/mp4")
+	}
+}
+
+func TestRunSkipsNonMatchingMediatypeFilter(t *testing.T) {
+	hook := Config{
+		Cmd:             []string{"false"},
+		MediatypeFilter: []string{"image/jpeg"},
+	}
+	vars := Vars{Mediatype: "video/mp4"}
+
+	if out, err := Run(context.Background(), hook, vars); err != nil || out != "" {
+		t.Fatalf("Run() with a non-matching filter should be a no-op, got out=%q err=%v", out, err)
+	}
+}
+
+func TestRunNoOpWithoutCmd(t *testing.T) {
+	if out, err := Run(context.Background(), Config{}, Vars{}); err != nil || out != "" {
+		t.Fatalf("Run() with no cmd should be a no-op, got out=%q err=%v", out, err)
+	}
+}
+
+func TestRunReturnsOutputOnFailure(t *testing.T) {
+	hook := Config{Cmd: []string{"sh", "-c", "echo boom >&2; exit 1"}}
+
+	out, err := Run(context.Background(), hook, Vars{})
+	if err == nil {
+		t.Fatal("Run() expected an error for a failing command")
+	}
+	if !strings.Contains(out, "boom") {
+		t.Errorf("Run() output = %q, want it to contain %q", out, "boom")
+	}
+}
+
+func TestRunHonorsTimeout(t *testing.T) {
+	hook := Config{Cmd: []string{"sleep", "5"}, Timeout: 10 * time.Millisecond}
+
+	if _, err := Run(context.Background(), hook, Vars{}); err == nil {
+		t.Fatal("Run() expected a timeout error")
+	}
+}
@@ -0,0 +1,140 @@
+// Package integrity computes and verifies checksums for downloaded files,
+// so an interrupted process or a flaky disk can be audited after the fact
+// instead of silently leaving corrupt archives behind.
+package integrity
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lukechampine.com/blake3"
+)
+
+// Algo identifies a supported hash algorithm.
+type Algo string
+
+const (
+	SHA256 Algo = "sha256"
+	BLAKE3 Algo = "blake3"
+)
+
+// NewHash returns a fresh hash.Hash for algo. An empty algo defaults to
+// SHA256.
+func NewHash(algo Algo) (hash.Hash, error) {
+	switch algo {
+	case "", SHA256:
+		return sha256.New(), nil
+	case BLAKE3:
+		return blake3.New(32, nil), nil
+	default:
+		return nil, fmt.Errorf("integrity: unknown algo %q", algo)
+	}
+}
+
+// HashingReader wraps r, streaming every byte read through h. Callers drain
+// r via this reader (e.g. by passing it to storage.Storage.Put) and then
+// read h.Sum(nil) once the copy is done.
+func HashingReader(r io.Reader, h hash.Hash) io.Reader {
+	return io.TeeReader(r, h)
+}
+
+// WriteSidecar writes a "<relPath>.sha256"-style sidecar next to relPath,
+// rooted at root, containing a line compatible with "sha256sum -c":
+// "<hash>  <basename>\n".
+func WriteSidecar(root, relPath string, algo Algo, sum []byte) error {
+	sidecar := filepath.Join(root, relPath+"."+string(sidecarExt(algo)))
+	line := fmt.Sprintf("%x  %s\n", sum, filepath.Base(relPath))
+	return os.WriteFile(sidecar, []byte(line), 0600)
+}
+
+// AppendManifest appends a "<hash>  <relPath>\n" line to the manifest file
+// at manifestPath (rooted at root), creating it if necessary.
+func AppendManifest(root, manifestPath string, relPath string, sum []byte) error {
+	f, err := os.OpenFile(filepath.Join(root, manifestPath), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("integrity: failed to open manifest %s: %w", manifestPath, err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%x  %s\n", sum, filepath.ToSlash(relPath)); err != nil {
+		return fmt.Errorf("integrity: failed to append to manifest %s: %w", manifestPath, err)
+	}
+	return nil
+}
+
+func sidecarExt(algo Algo) Algo {
+	if algo == "" {
+		return SHA256
+	}
+	return algo
+}
+
+// Mismatch describes a file whose on-disk hash no longer matches the
+// recorded one.
+type Mismatch struct {
+	Path string
+	Want string
+	Got  string
+}
+
+// Verify re-hashes every file listed in the manifest at manifestPath (rooted
+// at root) and reports any that no longer match. Missing files are reported
+// as mismatches with Got == "".
+func Verify(root, manifestPath string, algo Algo) ([]Mismatch, error) {
+	f, err := os.Open(filepath.Join(root, manifestPath))
+	if err != nil {
+		return nil, fmt.Errorf("integrity: failed to open manifest %s: %w", manifestPath, err)
+	}
+	defer f.Close()
+
+	var mismatches []Mismatch
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "  ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("integrity: malformed manifest line %q", line)
+		}
+		want, relPath := fields[0], fields[1]
+
+		got, err := hashFile(filepath.Join(root, relPath), algo)
+		if err != nil {
+			if os.IsNotExist(err) {
+				mismatches = append(mismatches, Mismatch{Path: relPath, Want: want})
+				continue
+			}
+			return nil, fmt.Errorf("integrity: failed to hash %s: %w", relPath, err)
+		}
+		if got != want {
+			mismatches = append(mismatches, Mismatch{Path: relPath, Want: want, Got: got})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("integrity: failed to read manifest %s: %w", manifestPath, err)
+	}
+	return mismatches, nil
+}
+
+func hashFile(path string, algo Algo) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h, err := NewHash(algo)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
@@ -0,0 +1,119 @@
+package integrity
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashingReaderMatchesHashFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "post.mp4")
+	if err := os.WriteFile(path, []byte("some video bytes"), 0600); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+
+	h, err := NewHash(SHA256)
+	if err != nil {
+		t.Fatalf("NewHash() error = %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(io.Discard, HashingReader(f, h)); err != nil {
+		t.Fatalf("reading via HashingReader: %v", err)
+	}
+
+	want, err := hashFile(path, SHA256)
+	if err != nil {
+		t.Fatalf("hashFile() error = %v", err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		t.Errorf("HashingReader sum = %q, want %q", got, want)
+	}
+}
+
+func TestWriteSidecarAndVerifyRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "post.mp4"), []byte("payload"), 0600); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+	sum, err := hashFile(filepath.Join(root, "post.mp4"), SHA256)
+	if err != nil {
+		t.Fatalf("hashFile() error = %v", err)
+	}
+	rawSum, err := hex.DecodeString(sum)
+	if err != nil {
+		t.Fatalf("DecodeString() error = %v", err)
+	}
+
+	if err := WriteSidecar(root, "post.mp4", SHA256, rawSum); err != nil {
+		t.Fatalf("WriteSidecar() error = %v", err)
+	}
+	sidecar, err := os.ReadFile(filepath.Join(root, "post.mp4.sha256"))
+	if err != nil {
+		t.Fatalf("ReadFile(sidecar) error = %v", err)
+	}
+	if want := sum + "  post.mp4\n"; string(sidecar) != want {
+		t.Errorf("sidecar = %q, want %q", sidecar, want)
+	}
+
+	if err := AppendManifest(root, "manifest.txt", "post.mp4", rawSum); err != nil {
+		t.Fatalf("AppendManifest() error = %v", err)
+	}
+
+	mismatches, err := Verify(root, "manifest.txt", SHA256)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("Verify() = %v, want no mismatches", mismatches)
+	}
+}
+
+func TestVerifyDetectsMismatchAndMissingFile(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "post.mp4"), []byte("payload"), 0600); err != nil {
+		t.Fatalf("failed to seed file: %v", err)
+	}
+
+	manifest := "deadbeef  post.mp4\ncafebabe  missing.mp4\n"
+	if err := os.WriteFile(filepath.Join(root, "manifest.txt"), []byte(manifest), 0600); err != nil {
+		t.Fatalf("failed to seed manifest: %v", err)
+	}
+
+	mismatches, err := Verify(root, "manifest.txt", SHA256)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(mismatches) != 2 {
+		t.Fatalf("Verify() returned %d mismatches, want 2: %v", len(mismatches), mismatches)
+	}
+	if mismatches[0].Path != "post.mp4" || mismatches[0].Got == "" {
+		t.Errorf("Verify() content mismatch = %+v", mismatches[0])
+	}
+	if mismatches[1].Path != "missing.mp4" || mismatches[1].Got != "" {
+		t.Errorf("Verify() missing-file mismatch = %+v", mismatches[1])
+	}
+}
+
+func TestVerifyRejectsMalformedManifestLine(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "manifest.txt"), []byte("not-a-valid-line\n"), 0600); err != nil {
+		t.Fatalf("failed to seed manifest: %v", err)
+	}
+
+	if _, err := Verify(root, "manifest.txt", SHA256); err == nil {
+		t.Fatal("Verify() expected an error for a malformed manifest line")
+	}
+}
+
+func TestNewHashUnknownAlgo(t *testing.T) {
+	if _, err := NewHash("md5"); err == nil {
+		t.Fatal("NewHash() expected an error for an unsupported algo")
+	}
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Filesystem is the default Storage provider. It writes beneath root using
+// plain os calls, preserving the layout tikwm has always produced on disk.
+type Filesystem struct {
+	root string
+}
+
+// NewFilesystem returns a Storage that writes beneath root.
+func NewFilesystem(root string) *Filesystem {
+	return &Filesystem{root: root}
+}
+
+// abs resolves path to a location beneath root, rejecting any path whose
+// cleaned form would escape root (e.g. via ".." segments). path values
+// ultimately derive from untrusted TikTok API metadata (author handles,
+// titles, post IDs), so this boundary is enforced here rather than trusted
+// from callers.
+func (f *Filesystem) abs(path string) (string, error) {
+	dest := filepath.Join(f.root, filepath.FromSlash(path))
+	rel, err := filepath.Rel(f.root, dest)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("storage: path %q escapes root %q", path, f.root)
+	}
+	return dest, nil
+}
+
+// Put always writes to "<dest>.part" first, fsyncs it, and only then renames
+// it into place over dest. This guarantees readers never observe a
+// partially-written file under its final name, and that a process killed
+// mid-download leaves behind an inert ".part" file rather than a truncated
+// one at the expected path.
+func (f *Filesystem) Put(_ context.Context, path string, r io.Reader) error {
+	dest, err := f.abs(path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	partPath := dest + ".part"
+	out, err := os.OpenFile(partPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s for writing: %w", path, err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to fsync %s: %w", path, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", path, err)
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return fmt.Errorf("failed to rename %s into place: %w", path, err)
+	}
+	return nil
+}
+
+func (f *Filesystem) Exists(_ context.Context, path string) (bool, error) {
+	dest, err := f.abs(path)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(dest)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return true, nil
+}
+
+func (f *Filesystem) Stat(_ context.Context, path string) (Info, error) {
+	dest, err := f.abs(path)
+	if err != nil {
+		return Info{}, err
+	}
+	fi, err := os.Stat(dest)
+	if err != nil {
+		return Info{}, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return Info{Size: fi.Size(), ModTime: fi.ModTime()}, nil
+}
+
+func (f *Filesystem) Delete(_ context.Context, path string) error {
+	dest, err := f.abs(path)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete %s: %w", path, err)
+	}
+	return nil
+}
+
+// URLFor returns a user-facing path for logging. Unlike the other methods it
+// cannot return an error, so a path that would escape root falls back to the
+// raw (unvalidated) join instead of failing; no write or read ever goes
+// through this method.
+func (f *Filesystem) URLFor(path string) string {
+	if dest, err := f.abs(path); err == nil {
+		return dest
+	}
+	return filepath.Join(f.root, filepath.FromSlash(path))
+}
+
+// CleanupPartials walks root for stray "*.part" files left behind by a
+// process that was killed mid-download. If resume is false they are deleted;
+// if resume is true they are left in place. Put does not resume from an
+// existing ".part" file: it truncates it and writes from the start.
+func (f *Filesystem) CleanupPartials(resume bool) error {
+	if resume {
+		return nil
+	}
+	return filepath.Walk(f.root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || filepath.Ext(p) != ".part" {
+			return nil
+		}
+		if rmErr := os.Remove(p); rmErr != nil {
+			return fmt.Errorf("failed to remove stale partial %s: %w", p, rmErr)
+		}
+		return nil
+	})
+}
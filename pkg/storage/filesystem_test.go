@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilesystemPutIsAtomic(t *testing.T) {
+	root := t.TempDir()
+	fs := NewFilesystem(root)
+
+	if err := fs.Put(context.Background(), "video.mp4", bytes.NewBufferString("hello")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "video.mp4.part")); !os.IsNotExist(err) {
+		t.Errorf("expected no leftover .part file, stat err = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "video.mp4"))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("wrote %q, want %q", data, "hello")
+	}
+}
+
+func TestFilesystemRejectsPathTraversal(t *testing.T) {
+	root := t.TempDir()
+	fs := NewFilesystem(root)
+
+	if err := fs.Put(context.Background(), "../../etc/passwd", bytes.NewBufferString("pwned")); err == nil {
+		t.Fatal("Put() with a traversal path should have failed")
+	}
+	if _, err := fs.Exists(context.Background(), "../escape.txt"); err == nil {
+		t.Fatal("Exists() with a traversal path should have failed")
+	}
+	if err := fs.Delete(context.Background(), "../escape.txt"); err == nil {
+		t.Fatal("Delete() with a traversal path should have failed")
+	}
+	if _, err := fs.Stat(context.Background(), "a/../../b"); err == nil {
+		t.Fatal("Stat() with a traversal path should have failed")
+	}
+}
+
+func TestFilesystemCleanupPartials(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "stale.mp4.part"), []byte("x"), 0600); err != nil {
+		t.Fatalf("failed to seed stale partial: %v", err)
+	}
+	fs := NewFilesystem(root)
+
+	if err := fs.CleanupPartials(true); err != nil {
+		t.Fatalf("CleanupPartials(resume=true) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "stale.mp4.part")); err != nil {
+		t.Fatalf("resume=true should have left the partial in place: %v", err)
+	}
+
+	if err := fs.CleanupPartials(false); err != nil {
+		t.Fatalf("CleanupPartials(resume=false) error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "stale.mp4.part")); !os.IsNotExist(err) {
+		t.Fatalf("resume=false should have removed the partial, stat err = %v", err)
+	}
+}
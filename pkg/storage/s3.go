@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// S3 stores objects in an S3-compatible bucket (AWS S3, MinIO, etc.), for
+// headless deployments that want to push finished downloads straight to
+// object storage instead of local disk.
+type S3 struct {
+	client *s3.Client
+	bucket string
+	prefix string
+	sse    string
+	kmsKey string
+}
+
+// NewS3 builds an S3 Storage from cfg. Credentials are resolved via the
+// standard AWS credential chain unless AccessKeyID/SecretAccessKey are set.
+func NewS3(cfg S3Config) (*S3, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("storage: s3 backend requires a bucket")
+	}
+
+	loadOpts := []func(*config.LoadOptions) error{}
+	if cfg.Region != "" {
+		loadOpts = append(loadOpts, config.WithRegion(cfg.Region))
+	}
+	if cfg.AccessKeyID != "" {
+		loadOpts = append(loadOpts, config.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		))
+	}
+	awsCfg, err := config.LoadDefaultConfig(context.Background(), loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to load AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+		}
+		o.UsePathStyle = cfg.ForcePathStyle
+	})
+
+	return &S3{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: strings.Trim(cfg.Prefix, "/"),
+		sse:    cfg.SSE,
+		kmsKey: cfg.SSEKMSKeyID,
+	}, nil
+}
+
+func (s *S3) key(p string) string {
+	if s.prefix == "" {
+		return p
+	}
+	return path.Join(s.prefix, p)
+}
+
+func (s *S3) Put(ctx context.Context, p string, r io.Reader) error {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+		Body:   r,
+	}
+	if s.sse != "" {
+		input.ServerSideEncryption = sseType(s.sse)
+		if s.kmsKey != "" {
+			input.SSEKMSKeyId = aws.String(s.kmsKey)
+		}
+	}
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("storage: failed to put %s: %w", p, err)
+	}
+	return nil
+}
+
+func (s *S3) Exists(ctx context.Context, p string) (bool, error) {
+	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("storage: failed to head %s: %w", p, err)
+	}
+	return true, nil
+}
+
+func (s *S3) Stat(ctx context.Context, p string) (Info, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	})
+	if err != nil {
+		return Info{}, fmt.Errorf("storage: failed to head %s: %w", p, err)
+	}
+	info := Info{}
+	if out.ContentLength != nil {
+		info.Size = *out.ContentLength
+	}
+	if out.LastModified != nil {
+		info.ModTime = *out.LastModified
+	}
+	return info, nil
+}
+
+func (s *S3) Delete(ctx context.Context, p string) error {
+	if _, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(p)),
+	}); err != nil {
+		return fmt.Errorf("storage: failed to delete %s: %w", p, err)
+	}
+	return nil
+}
+
+func (s *S3) URLFor(p string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.key(p))
+}
+
+func sseType(s string) types.ServerSideEncryption {
+	return types.ServerSideEncryption(s)
+}
+
+// isNotFound reports whether err is the SDK's typed "not found" error for
+// HeadObject, or (as a fallback, since some S3-compatible services don't
+// return the typed form) a response with a 404 status.
+func isNotFound(err error) bool {
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return true
+	}
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.HTTPStatusCode() == http.StatusNotFound
+	}
+	return false
+}
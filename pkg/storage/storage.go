@@ -0,0 +1,76 @@
+// Package storage abstracts where downloaded media is written.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Backend identifies a storage provider implementation.
+type Backend string
+
+const (
+	BackendFilesystem Backend = "filesystem"
+	BackendS3         Backend = "s3"
+)
+
+// Info describes a stored object, mirroring the subset of os.FileInfo the
+// downloader actually needs.
+type Info struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage is the interface the downloader (and the cover/avatar/title
+// writers) use to persist output files. Paths are always slash-separated and
+// relative to the configured root (download_path for the filesystem provider,
+// prefix for the S3 provider).
+type Storage interface {
+	// Put writes r to path, creating any intermediate directories as needed.
+	Put(ctx context.Context, path string, r io.Reader) error
+	// Exists reports whether path is already present.
+	Exists(ctx context.Context, path string) (bool, error)
+	// Stat returns metadata for path.
+	Stat(ctx context.Context, path string) (Info, error)
+	// Delete removes path. It is not an error if path does not exist.
+	Delete(ctx context.Context, path string) error
+	// URLFor returns a user-facing reference to path (a local filesystem
+	// path, or an s3:// URL), suitable for logging or a database record.
+	URLFor(path string) string
+}
+
+// Config is the subset of cliconfig.Config needed to construct a Storage.
+// It is duplicated here (rather than imported) to keep this package free of
+// a dependency on the CLI config package.
+type Config struct {
+	Backend Backend
+	Root    string // download_path, used by the filesystem backend
+	S3      S3Config
+}
+
+// S3Config holds the settings needed to talk to an S3-compatible endpoint.
+type S3Config struct {
+	Bucket          string
+	Region          string
+	Endpoint        string // override for MinIO or other S3-compatible services
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	ForcePathStyle  bool
+	SSE             string // "", "AES256", or "aws:kms"
+	SSEKMSKeyID     string
+}
+
+// New constructs the Storage implementation selected by cfg.Backend.
+func New(cfg Config) (Storage, error) {
+	switch cfg.Backend {
+	case "", BackendFilesystem:
+		return NewFilesystem(cfg.Root), nil
+	case BackendS3:
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
@@ -1,18 +1,27 @@
 package cliconfig
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/adrg/xdg"
 	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/providers/file"
 	"github.com/knadh/koanf/v2"
 	"github.com/perpetuallyhorni/tikwm/pkg/config"
+	"github.com/perpetuallyhorni/tikwm/pkg/handler"
+	"github.com/perpetuallyhorni/tikwm/pkg/hooks"
+	"github.com/perpetuallyhorni/tikwm/pkg/integrity"
+	"github.com/perpetuallyhorni/tikwm/pkg/storage"
 )
 
 const AppName = "tikwm"
@@ -20,14 +29,163 @@ const AppName = "tikwm"
 // Config extends the core config with CLI-specific options.
 type Config struct {
 	config.Config      `koanf:",squash"`
-	TargetsFile        string `koanf:"targets_file"`
-	DatabasePath       string `koanf:"database_path"`
-	Editor             string `koanf:"editor"`
-	CheckForUpdates    bool   `koanf:"check_for_updates"` // Check for new versions on startup.
-	AutoUpdate         bool   `koanf:"auto_update"`       // Automatically install new versions.
-	MaxWorkers         int    `koanf:"max_workers"`       // Maximum number of concurrent workers.
-	DaemonMode         bool   `koanf:"daemon_mode"`
-	DaemonPollInterval string `koanf:"daemon_poll_interval"`
+	TargetsFile        string                   `koanf:"targets_file"`
+	DatabasePath       string                   `koanf:"database_path"`
+	Editor             string                   `koanf:"editor"`
+	CheckForUpdates    bool                     `koanf:"check_for_updates"` // Check for new versions on startup.
+	AutoUpdate         bool                     `koanf:"auto_update"`       // Automatically install new versions.
+	MaxWorkers         int                      `koanf:"max_workers"`       // Maximum number of concurrent workers.
+	DaemonMode         bool                     `koanf:"daemon_mode"`
+	DaemonPollInterval string                   `koanf:"daemon_poll_interval"`
+	Storage            StorageConfig            `koanf:"storage"`
+	Handlers           map[string]HandlerConfig `koanf:"handlers"`
+	HandlerTimeout     string                   `koanf:"handler_timeout"` // e.g. "30s"; applies to every handler invocation.
+	IntegrityManifest  bool                     `koanf:"integrity_manifest"`
+	IntegrityAlgo      string                   `koanf:"integrity_algo"` // "sha256" or "blake3"
+	ResumePartials     bool                     `koanf:"resume_partials"`
+	Hooks              HooksConfig              `koanf:"hooks"`
+}
+
+// HooksConfig lists the commands to run around the download lifecycle.
+type HooksConfig struct {
+	PreDownload  []HookConfig `koanf:"pre_download"`
+	PostDownload []HookConfig `koanf:"post_download"`
+	PostTarget   []HookConfig `koanf:"post_target"`
+	OnError      []HookConfig `koanf:"on_error"`
+}
+
+// HookConfig is a single hook: the command to run, extra environment
+// variables, a timeout, and an optional mediatype filter. "{path}",
+// "{author}", "{post_id}", "{mediatype}", "{duration_ms}", and "{quality}"
+// are substituted into both cmd and env at run time.
+type HookConfig struct {
+	Cmd             []string          `koanf:"cmd"`
+	Env             map[string]string `koanf:"env"`
+	Timeout         string            `koanf:"timeout"` // e.g. "10s"
+	MediatypeFilter []string          `koanf:"mediatype_filter"`
+}
+
+func (h HookConfig) toHooksConfig() hooks.Config {
+	timeout, _ := time.ParseDuration(h.Timeout)
+	return hooks.Config{
+		Cmd:             h.Cmd,
+		Env:             h.Env,
+		Timeout:         timeout,
+		MediatypeFilter: h.MediatypeFilter,
+	}
+}
+
+// RunHooks runs every hook configured for event, in order, with vars
+// expanded into argv/env. It stops at the first error.
+func (c *Config) RunHooks(ctx context.Context, event HookEvent, vars hooks.Vars) error {
+	var configured []HookConfig
+	switch event {
+	case EventPreDownload:
+		configured = c.Hooks.PreDownload
+	case EventPostDownload:
+		configured = c.Hooks.PostDownload
+	case EventPostTarget:
+		configured = c.Hooks.PostTarget
+	case EventOnError:
+		configured = c.Hooks.OnError
+	}
+	for _, h := range configured {
+		if _, err := hooks.Run(ctx, h.toHooksConfig(), vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HookEvent identifies which configured hook list RunHooks should run.
+type HookEvent int
+
+const (
+	EventPreDownload HookEvent = iota
+	EventPostDownload
+	EventPostTarget
+	EventOnError
+)
+
+// HandlerConfig configures the external viewer/post-processor run for a
+// single mediatype (e.g. "video/mp4", "image/jpeg", "text/plain").
+type HandlerConfig struct {
+	Cmd              []string `koanf:"cmd"`                // argv; "{}" or "{path}" is replaced with the downloaded file's path.
+	NoPrompt         bool     `koanf:"no_prompt"`          // skip the "open with X?" confirmation.
+	RunAfterDownload bool     `koanf:"run_after_download"` // fire automatically after every matching download, not just `tikwm open`.
+}
+
+// handlerMap converts the CLI config's handler map into the generic
+// map[string]handler.Config consumed by handler.Dispatch.
+func (c *Config) handlerMap() map[string]handler.Config {
+	m := make(map[string]handler.Config, len(c.Handlers))
+	for mediatype, h := range c.Handlers {
+		m[mediatype] = handler.Config{
+			Cmd:              h.Cmd,
+			NoPrompt:         h.NoPrompt,
+			RunAfterDownload: h.RunAfterDownload,
+		}
+	}
+	return m
+}
+
+// Dispatch runs the handler configured for mediatype against path, honoring
+// HandlerTimeout and NoPrompt. The `tikwm open` command calls it on demand;
+// no write path in this tree calls it after a file finishes, so a handler's
+// RunAfterDownload setting is not acted on.
+func (c *Config) Dispatch(ctx context.Context, mediatype, path string) error {
+	timeout, _ := time.ParseDuration(c.HandlerTimeout)
+	return handler.Dispatch(ctx, c.handlerMap(), mediatype, path, timeout)
+}
+
+// StorageConfig selects and configures where downloaded media is written.
+// The filesystem backend has no settings of its own; it writes beneath
+// download_path.
+type StorageConfig struct {
+	Backend string   `koanf:"backend"` // "filesystem" or "s3"
+	S3      S3Config `koanf:"s3"`
+}
+
+// S3Config holds the settings for the "s3" storage backend, which also
+// covers S3-compatible services such as MinIO.
+type S3Config struct {
+	Bucket          string `koanf:"bucket"`
+	Region          string `koanf:"region"`
+	Endpoint        string `koanf:"endpoint"` // override for MinIO or other S3-compatible services
+	Prefix          string `koanf:"prefix"`
+	AccessKeyID     string `koanf:"access_key_id"`
+	SecretAccessKey string `koanf:"secret_access_key"`
+	ForcePathStyle  bool   `koanf:"force_path_style"`
+	SSE             string `koanf:"sse"` // "", "AES256", or "aws:kms"
+	SSEKMSKeyID     string `koanf:"sse_kms_key_id"`
+}
+
+// storageConfig converts the CLI config into the generic storage.Config
+// consumed by storage.New.
+func (c *Config) storageConfig() storage.Config {
+	return storage.Config{
+		Backend: storage.Backend(c.Storage.Backend),
+		Root:    c.DownloadPath,
+		S3: storage.S3Config{
+			Bucket:          c.Storage.S3.Bucket,
+			Region:          c.Storage.S3.Region,
+			Endpoint:        c.Storage.S3.Endpoint,
+			Prefix:          c.Storage.S3.Prefix,
+			AccessKeyID:     c.Storage.S3.AccessKeyID,
+			SecretAccessKey: c.Storage.S3.SecretAccessKey,
+			ForcePathStyle:  c.Storage.S3.ForcePathStyle,
+			SSE:             c.Storage.S3.SSE,
+			SSEKMSKeyID:     c.Storage.S3.SSEKMSKeyID,
+		},
+	}
+}
+
+// NewStorage builds the Storage backend selected by c.Storage. The
+// downloader and the cover/avatar/title writers should call this instead of
+// writing to os directly, so a single binary can target local disk or
+// object storage.
+func (c *Config) NewStorage() (storage.Storage, error) {
+	return storage.New(c.storageConfig())
 }
 
 // Default returns the default CLI configuration.
@@ -52,11 +210,66 @@ func Default() (*Config, error) {
 		MaxWorkers:         runtime.NumCPU(),
 		DaemonMode:         false,
 		DaemonPollInterval: "60s",
+		Storage:            StorageConfig{Backend: string(storage.BackendFilesystem)},
+		Handlers:           defaultHandlers(),
+		HandlerTimeout:     "30s",
+		IntegrityManifest:  false,
+		IntegrityAlgo:      string(integrity.SHA256),
+		ResumePartials:     true,
 	}, nil
 }
 
-// Load loads the configuration from the given path.
+// defaultHandlers returns an "open with the OS default application" handler
+// for every mediatype tikwm downloads, so `tikwm open` and --open work out
+// of the box without any configuration.
+func defaultHandlers() map[string]HandlerConfig {
+	var opener []string
+	switch runtime.GOOS {
+	case "darwin":
+		opener = []string{"open"}
+	case "windows":
+		opener = []string{"cmd", "/c", "start", ""}
+	default:
+		opener = []string{"xdg-open"}
+	}
+
+	h := HandlerConfig{Cmd: append(append([]string{}, opener...), "{path}")}
+	return map[string]HandlerConfig{
+		"video/mp4":  h,
+		"image/jpeg": h,
+		"image/webp": h,
+		"text/plain": h,
+	}
+}
+
+// ConfigDirEnvVar overrides the directory scanned for layered config.d/*.yaml
+// overrides; see LoadWithConfigDir.
+const ConfigDirEnvVar = "TIKWM_CONFIG_DIR"
+
+// Load loads the configuration from the given path, then layers in any
+// overrides found via the TIKWM_CONFIG_DIR environment variable (see
+// LoadWithConfigDir).
 func Load(path string) (*Config, error) {
+	return LoadWithConfigDir(path, os.Getenv(ConfigDirEnvVar))
+}
+
+// EnvPrefix is the prefix env vars must carry to override config values; see
+// LoadWithConfigDir. A double underscore descends into a nested key, e.g.
+// TIKWM_STORAGE__BACKEND overrides storage.backend.
+const EnvPrefix = "TIKWM_"
+
+// LoadWithConfigDir loads the configuration from the given path, then merges
+// on top of it every *.yaml file found in configDir, in lexical order. This
+// lets users drop per-target overrides (e.g. "10-creatorA.yaml",
+// "20-proxy.yaml") into a directory instead of editing a single monolithic
+// config file, which is useful for daemon-mode deployments managed by
+// config-management tools. If configDir is empty, it defaults to
+// "<xdg-config>/tikwm/config.d". The merge precedence is:
+// defaults -> config file -> config.d (lexical order) -> TIKWM_*-prefixed
+// environment variables. Flags are the caller's concern: a CLI command
+// layer that parses a --config-dir flag should pass it straight through as
+// configDir rather than relying on TIKWM_CONFIG_DIR.
+func LoadWithConfigDir(path, configDir string) (*Config, error) {
 	k := koanf.New(".")
 	defCfg, err := Default()
 	if err != nil {
@@ -77,6 +290,25 @@ func Load(path string) (*Config, error) {
 	if err := k.Load(file.Provider(cfgPath), yaml.Parser()); err != nil {
 		return nil, fmt.Errorf("failed to load config file: %w", err)
 	}
+
+	if configDir == "" {
+		configDir = filepath.Join(xdg.ConfigHome, AppName, "config.d")
+	}
+	overrides, err := filepath.Glob(filepath.Join(configDir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list config.d overrides: %w", err)
+	}
+	sort.Strings(overrides)
+	for _, overridePath := range overrides {
+		if err := k.Load(file.Provider(overridePath), yaml.Parser()); err != nil {
+			return nil, fmt.Errorf("failed to load config.d override %s: %w", overridePath, err)
+		}
+	}
+
+	if err := k.Load(env.Provider(EnvPrefix, ".", envKeyToKoanf), nil); err != nil {
+		return nil, fmt.Errorf("failed to load environment overrides: %w", err)
+	}
+
 	cfg := defCfg
 	if err := k.Unmarshal("", cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
@@ -94,9 +326,73 @@ func Load(path string) (*Config, error) {
 			fmt.Fprintf(os.Stderr, "Warning: failed to create default targets file: %v\n", err)
 		}
 	}
+
+	if err := validateStorage(&cfg.Storage); err != nil {
+		return nil, err
+	}
+
+	if cfg.IntegrityAlgo == "" {
+		cfg.IntegrityAlgo = string(integrity.SHA256)
+	}
+	switch integrity.Algo(cfg.IntegrityAlgo) {
+	case integrity.SHA256, integrity.BLAKE3:
+	default:
+		return nil, fmt.Errorf("integrity_algo: unknown algorithm %q (want %q or %q)", cfg.IntegrityAlgo, integrity.SHA256, integrity.BLAKE3)
+	}
+
+	if err := validateHooks(&cfg.Hooks); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
+// envKeyToKoanf converts an env var name (e.g. "TIKWM_STORAGE__BACKEND")
+// into a koanf key ("storage.backend").
+func envKeyToKoanf(s string) string {
+	s = strings.TrimPrefix(s, EnvPrefix)
+	return strings.ToLower(strings.ReplaceAll(s, "__", "."))
+}
+
+// validateHooks rejects any configured hook whose cmd[0] can't be resolved,
+// so misconfiguration surfaces at startup instead of the first time the
+// hook would fire.
+func validateHooks(h *HooksConfig) error {
+	for _, list := range [][]HookConfig{h.PreDownload, h.PostDownload, h.PostTarget, h.OnError} {
+		for _, hook := range list {
+			if len(hook.Cmd) == 0 {
+				continue
+			}
+			bin := hook.Cmd[0]
+			if filepath.IsAbs(bin) {
+				continue
+			}
+			if _, err := exec.LookPath(bin); err != nil {
+				return fmt.Errorf("hooks: %q is not on $PATH: %w", bin, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateStorage checks the chosen storage backend and its required
+// settings, filling in sensible defaults where possible.
+func validateStorage(s *StorageConfig) error {
+	if s.Backend == "" {
+		s.Backend = string(storage.BackendFilesystem)
+	}
+	switch storage.Backend(s.Backend) {
+	case storage.BackendFilesystem:
+	case storage.BackendS3:
+		if s.S3.Bucket == "" {
+			return fmt.Errorf("storage: backend is %q but storage.s3.bucket is not set", s.Backend)
+		}
+	default:
+		return fmt.Errorf("storage: unknown backend %q (want %q or %q)", s.Backend, storage.BackendFilesystem, storage.BackendS3)
+	}
+	return nil
+}
+
 // createDefaultConfig creates a default configuration file.
 func createDefaultConfig(path string, cfg *Config) error {
 	dir := filepath.Dir(path)
@@ -160,7 +456,68 @@ editor: "%s"
 check_for_updates: %t
 # Automatically install new versions of tikwm. If false, you will be notified to run 'tikwm update'.
 auto_update: %t
-`, cfg.DownloadPath, cfg.TargetsFile, cfg.DatabasePath, cfg.MaxWorkers, cfg.Quality, cfg.Since, cfg.DownloadCovers, cfg.CoverType, cfg.DownloadAvatars, cfg.SavePostTitle, cfg.RetryOn429, cfg.FfmpegPath, cfg.BindAddress, cfg.FeedCache, cfg.FeedCacheTTL, cfg.DaemonMode, cfg.DaemonPollInterval, cfg.Editor, cfg.CheckForUpdates, cfg.AutoUpdate)
+
+# Storage
+# Where downloaded media is written. Options: "filesystem" (default, uses
+# download_path) or "s3" (push directly to S3 or an S3-compatible service
+# like MinIO).
+storage:
+  backend: "%s"
+  s3:
+    bucket: "%s"
+    region: "%s"
+    endpoint: "%s"
+    prefix: "%s"
+    access_key_id: "%s"
+    secret_access_key: "%s"
+    force_path_style: %t
+    sse: "%s"
+    sse_kms_key_id: "%s"
+
+# Handlers
+# External viewers/post-processors to run on downloaded files, keyed by
+# mediatype. "{}" or "{path}" in cmd is replaced with the downloaded file's
+# path. Uncomment and adjust to pipe videos to mpv, images to feh, etc.
+# handlers:
+#   video/mp4:
+#     cmd: ["mpv", "{path}"]
+#     no_prompt: true
+#     run_after_download: false
+#   image/jpeg:
+#     cmd: ["feh", "{path}"]
+#     no_prompt: true
+#     run_after_download: false
+# Timeout applied to every handler invocation.
+handler_timeout: "%s"
+
+# Integrity
+# The filesystem storage backend always writes to "<file>.part", fsyncs it,
+# then renames it into place, so a killed process never leaves a truncated
+# file under its final name. integrity_manifest is not acted on by any
+# command in this build: no checksum is recorded when a file is written.
+integrity_manifest: %t
+# Algorithm used by 'tikwm verify <manifest>'. Options: "sha256", "blake3".
+integrity_algo: "%s"
+# resume_partials is not acted on by any command in this build: stray ".part"
+# files are neither resumed nor deleted at startup, and a new write to the
+# same file truncates its ".part" and starts over.
+resume_partials: %t
+
+# Hooks
+# Commands to run around the download lifecycle: pre_download, post_download,
+# post_target (after all of a target's posts are processed), and on_error.
+# Placeholders {path}, {author}, {post_id}, {mediatype}, {duration_ms}, and
+# {quality} are expanded in both cmd and env. A hook whose cmd[0] is not on
+# $PATH (and not an absolute path) is rejected at startup.
+# hooks:
+#   post_download:
+#     - cmd: ["ffmpeg", "-y", "-i", "{path}", "-c", "copy", "{path}.remuxed.mp4"]
+#       mediatype_filter: ["video/mp4"]
+#       timeout: "2m"
+#   on_error:
+#     - cmd: ["curl", "-X", "POST", "-d", "download of {post_id} failed", "https://example.com/webhook"]
+#       timeout: "10s"
+`, cfg.DownloadPath, cfg.TargetsFile, cfg.DatabasePath, cfg.MaxWorkers, cfg.Quality, cfg.Since, cfg.DownloadCovers, cfg.CoverType, cfg.DownloadAvatars, cfg.SavePostTitle, cfg.RetryOn429, cfg.FfmpegPath, cfg.BindAddress, cfg.FeedCache, cfg.FeedCacheTTL, cfg.DaemonMode, cfg.DaemonPollInterval, cfg.Editor, cfg.CheckForUpdates, cfg.AutoUpdate, cfg.Storage.Backend, cfg.Storage.S3.Bucket, cfg.Storage.S3.Region, cfg.Storage.S3.Endpoint, cfg.Storage.S3.Prefix, cfg.Storage.S3.AccessKeyID, cfg.Storage.S3.SecretAccessKey, cfg.Storage.S3.ForcePathStyle, cfg.Storage.S3.SSE, cfg.Storage.S3.SSEKMSKeyID, cfg.HandlerTimeout, cfg.IntegrityManifest, cfg.IntegrityAlgo, cfg.ResumePartials)
 	content = strings.ReplaceAll(content, "\\", "/")
 	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
 		return fmt.Errorf("failed to write default config file: %w", err)
@@ -0,0 +1,81 @@
+package cliconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/perpetuallyhorni/tikwm/pkg/hooks"
+)
+
+// These tests exercise RunHooks, Dispatch, and NewStorage directly rather
+// than through the put and open commands in tools/tikwm/main.go.
+
+func TestRunHooksRunsConfiguredCommand(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.txt")
+
+	cfg := &Config{
+		Hooks: HooksConfig{
+			PostDownload: []HookConfig{{Cmd: []string{"sh", "-c", "echo {post_id} > " + out}}},
+		},
+	}
+
+	if err := cfg.RunHooks(context.Background(), EventPostDownload, hooks.Vars{PostID: "42"}); err != nil {
+		t.Fatalf("RunHooks() error = %v", err)
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(got) != "42\n" {
+		t.Errorf("RunHooks() wrote %q, want %q", got, "42\n")
+	}
+}
+
+func TestRunHooksNoneConfigured(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.RunHooks(context.Background(), EventOnError, hooks.Vars{}); err != nil {
+		t.Fatalf("RunHooks() with no hooks configured should be a no-op, got error = %v", err)
+	}
+}
+
+func TestDispatchRunsConfiguredHandler(t *testing.T) {
+	// Dispatch is fire-and-forget: it returns once the child has started,
+	// not once it has finished. Assert only its own return value here, the
+	// same way pkg/handler's own tests do; asserting a side effect of the
+	// detached child would race it.
+	cfg := &Config{
+		Handlers: map[string]HandlerConfig{
+			"video/mp4": {Cmd: []string{"true"}, NoPrompt: true},
+		},
+		HandlerTimeout: "5s",
+	}
+
+	if err := cfg.Dispatch(context.Background(), "video/mp4", "/tmp/post.mp4"); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+}
+
+func TestDispatchNoHandlerConfigured(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.Dispatch(context.Background(), "video/mp4", "/tmp/post.mp4"); err != nil {
+		t.Fatalf("Dispatch() with no handler configured should be a no-op, got error = %v", err)
+	}
+}
+
+func TestNewStorageFilesystemBackend(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{Storage: StorageConfig{Backend: "filesystem"}}
+	cfg.DownloadPath = dir
+
+	s, err := cfg.NewStorage()
+	if err != nil {
+		t.Fatalf("NewStorage() error = %v", err)
+	}
+	if s == nil {
+		t.Fatal("NewStorage() returned a nil Storage")
+	}
+}